@@ -0,0 +1,467 @@
+// Package dbf reads and writes dBASE III style .dbf files.
+//
+// A DbfTable models the file as a fixed set of typed fields plus a slice of
+// records, each of which is either live or soft-deleted (the dBASE deletion
+// flag). Records are addressed by their zero-based row number, which stays
+// stable for the lifetime of the table even across deletes.
+package dbf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/encoding"
+)
+
+// Field types, using the single-byte codes dBASE stores in the field
+// descriptor.
+const (
+	Character = 'C'
+	Number    = 'N'
+	Float     = 'F'
+	Logical   = 'L'
+	Date      = 'D'
+	Memo      = 'M'
+	General   = 'G'
+)
+
+const (
+	headerSize     = 32
+	fieldDescSize  = 32
+	fieldNameSize  = 11
+	fieldArrayEnd  = 0x0D
+	fileEnd        = 0x1A
+	deletedFlag    = '*'
+	notDeletedFlag = ' '
+)
+
+// FieldDescriptor describes one column of a DbfTable: its on-disk name,
+// dBASE type code, byte length and, for Float fields, decimal precision.
+type FieldDescriptor struct {
+	Name    string
+	Type    byte
+	Length  int
+	Decimal int
+}
+
+// DbfTable is an in-memory dBASE table. Field values are kept as trimmed
+// strings; binary fixed-width encoding only happens in SaveFile/LoadFile.
+//
+// Its fields, records, deletion flags, indexes and encoding are safe for
+// concurrent use: every method that reads or writes them takes mu, so a
+// DbfTable can be shared across HTTP handlers or worker goroutines directly.
+// Memo/General field values are additionally guarded by memoMu, since they
+// live in a separate .dbt sidecar file rather than in dt itself (see
+// setMemoValue). Iterators snapshot the record count and deletion flags at
+// construction, so a long-running scan isn't disturbed by concurrent
+// appends; the field values they read through Values/Read are still live,
+// not snapshotted. Update additionally serializes whole transactions
+// against each other (see Update), so a failed transaction's rollback can
+// never undo a different transaction's already-committed write.
+type DbfTable struct {
+	mu sync.RWMutex
+
+	// txMu serializes whole Update transactions (not just the individual
+	// field/record accesses mu covers), so two concurrent Updates can't
+	// interleave their reads and writes or roll back each other's commits.
+	// See Update.
+	txMu sync.Mutex
+
+	// memoMu serializes reads and writes of dt's .dbt sidecar file: the
+	// block-count header read and the two writes that follow it in
+	// appendMemoBlock aren't atomic on their own. See setMemoValue.
+	memoMu sync.RWMutex
+
+	fields   []FieldDescriptor
+	fieldIdx map[string]int
+	records  [][]string
+	deleted  []bool
+	modified time.Time
+
+	// path is the file this table was loaded from or last saved to. It's
+	// empty for tables that only exist in memory, and is used by
+	// Update/View to locate the WAL sidecar file.
+	path string
+
+	// indexes holds the secondary indexes created with CreateIndex or
+	// loaded from sidecar files by LoadFile, keyed by name.
+	indexes map[string]*Index
+
+	// encoding and invalidMode control the code page SaveFile/LoadFile
+	// transcode field values to/from, identified by the header's LDID byte;
+	// see SetEncoding. A nil encoding means bytes pass through unchanged.
+	encoding    encoding.Encoding
+	invalidMode InvalidEncodingMode
+}
+
+// New creates an empty table with no fields and no records.
+func New() *DbfTable {
+	return &DbfTable{
+		fieldIdx: make(map[string]int),
+		modified: time.Now(),
+	}
+}
+
+func (dt *DbfTable) addField(name string, typ byte, length, decimal int) int {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	idx := len(dt.fields)
+	dt.fields = append(dt.fields, FieldDescriptor{Name: name, Type: typ, Length: length, Decimal: decimal})
+	dt.fieldIdx[strings.ToLower(name)] = idx
+	for row := range dt.records {
+		dt.records[row] = append(dt.records[row], "")
+	}
+	return idx
+}
+
+// AddBoolField adds a Logical ('L') field and returns its index.
+func (dt *DbfTable) AddBoolField(name string) int {
+	return dt.addField(name, Logical, 1, 0)
+}
+
+// AddTextField adds a Character ('C') field of the given byte length and
+// returns its index.
+func (dt *DbfTable) AddTextField(name string, length int) int {
+	return dt.addField(name, Character, length, 0)
+}
+
+// AddIntField adds a Number ('N') field with zero decimals and returns its
+// index.
+func (dt *DbfTable) AddIntField(name string, length int) int {
+	return dt.addField(name, Number, length, 0)
+}
+
+// AddFloatField adds a Float ('F') field with the given length and decimal
+// precision and returns its index.
+func (dt *DbfTable) AddFloatField(name string, length, decimal int) int {
+	return dt.addField(name, Float, length, decimal)
+}
+
+// AddDateField adds a Date ('D') field, stored on disk as YYYYMMDD, and
+// returns its index.
+func (dt *DbfTable) AddDateField(name string) int {
+	return dt.addField(name, Date, 8, 0)
+}
+
+// NumFields returns the number of fields in the table.
+func (dt *DbfTable) NumFields() int {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	return len(dt.fields)
+}
+
+// NumRecords returns the number of records in the table, including deleted
+// ones.
+func (dt *DbfTable) NumRecords() int {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	return len(dt.records)
+}
+
+// Fields returns the table's field descriptors, in column order.
+func (dt *DbfTable) Fields() []FieldDescriptor {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	return dt.fields
+}
+
+// FieldIndex returns the index of the named field, or -1 if there is none.
+func (dt *DbfTable) FieldIndex(name string) int {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	if idx, ok := dt.fieldIdx[strings.ToLower(name)]; ok {
+		return idx
+	}
+	return -1
+}
+
+// AddRecord appends a new, empty record and returns its row number.
+func (dt *DbfTable) AddRecord() int {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	row := len(dt.records)
+	dt.records = append(dt.records, make([]string, len(dt.fields)))
+	dt.deleted = append(dt.deleted, false)
+	return row
+}
+
+// Delete marks row as deleted. Deleted rows are skipped by Iterator but keep
+// their row number.
+func (dt *DbfTable) Delete(row int) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	dt.deleted[row] = true
+}
+
+// IsDeleted reports whether row is marked as deleted.
+func (dt *DbfTable) IsDeleted(row int) bool {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	return dt.deleted[row]
+}
+
+// Row returns a copy of the field values of row, in column order.
+func (dt *DbfTable) Row(row int) []string {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	out := make([]string, len(dt.records[row]))
+	copy(out, dt.records[row])
+	return out
+}
+
+// FieldValue returns the value of field in row.
+func (dt *DbfTable) FieldValue(row, field int) string {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	return dt.records[row][field]
+}
+
+// SetFieldValue sets the value of field in row.
+func (dt *DbfTable) SetFieldValue(row, field int, value string) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	dt.records[row][field] = value
+}
+
+// FieldValueByName returns the value of the named field in row.
+func (dt *DbfTable) FieldValueByName(row int, name string) string {
+	return dt.FieldValue(row, dt.FieldIndex(name))
+}
+
+// SetFieldValueByName sets the value of the named field in row.
+func (dt *DbfTable) SetFieldValueByName(row int, name, value string) {
+	dt.SetFieldValue(row, dt.FieldIndex(name), value)
+}
+
+// Clone returns a deep copy of dt's fields, field index, records and
+// deletion flags, independent of any later mutation to dt or the clone.
+// It's a copy-on-write style snapshot for callers that need a consistent
+// view to read from while dt keeps changing underneath them; the clone
+// doesn't carry over dt's path, indexes, or encoding, since it isn't
+// backed by the same file.
+func (dt *DbfTable) Clone() *DbfTable {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	fields := make([]FieldDescriptor, len(dt.fields))
+	copy(fields, dt.fields)
+
+	fieldIdx := make(map[string]int, len(dt.fieldIdx))
+	for k, v := range dt.fieldIdx {
+		fieldIdx[k] = v
+	}
+
+	records := make([][]string, len(dt.records))
+	for i, r := range dt.records {
+		row := make([]string, len(r))
+		copy(row, r)
+		records[i] = row
+	}
+
+	deleted := make([]bool, len(dt.deleted))
+	copy(deleted, dt.deleted)
+
+	return &DbfTable{
+		fields:   fields,
+		fieldIdx: fieldIdx,
+		records:  records,
+		deleted:  deleted,
+		modified: dt.modified,
+	}
+}
+
+// SaveFile writes the table to fileName in dBASE III format and records
+// fileName as dt's path, so a later Update or View knows where to find its
+// WAL sidecar.
+func (dt *DbfTable) SaveFile(fileName string) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := dt.encodeTo(f); err != nil {
+		return err
+	}
+	dt.path = fileName
+	return nil
+}
+
+// encodeTo writes the table to w in dBASE III format.
+func (dt *DbfTable) encodeTo(w io.Writer) error {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+
+	recordSize := 1 // deletion flag
+	for _, f := range dt.fields {
+		recordSize += f.Length
+	}
+	headerLen := headerSize + len(dt.fields)*fieldDescSize + 1
+
+	var header [headerSize]byte
+	header[0] = 0x03
+	y, m, d := dt.modified.Date()
+	header[1], header[2], header[3] = byte(y-1900), byte(m), byte(d)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(dt.records)))
+	binary.LittleEndian.PutUint16(header[8:10], uint16(headerLen))
+	binary.LittleEndian.PutUint16(header[10:12], uint16(recordSize))
+	header[29] = ldidFor(dt.resolvedEncoding())
+	if _, err := bw.Write(header[:]); err != nil {
+		return err
+	}
+
+	for _, f := range dt.fields {
+		var desc [fieldDescSize]byte
+		copy(desc[0:fieldNameSize], f.Name)
+		desc[11] = f.Type
+		desc[16] = byte(f.Length)
+		desc[17] = byte(f.Decimal)
+		if _, err := bw.Write(desc[:]); err != nil {
+			return err
+		}
+	}
+	if err := bw.WriteByte(fieldArrayEnd); err != nil {
+		return err
+	}
+
+	for row := range dt.records {
+		if err := dt.writeRecord(bw, row); err != nil {
+			return err
+		}
+	}
+	if err := bw.WriteByte(fileEnd); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (dt *DbfTable) writeRecord(w *bufio.Writer, row int) error {
+	flag := byte(notDeletedFlag)
+	if dt.deleted[row] {
+		flag = deletedFlag
+	}
+	if err := w.WriteByte(flag); err != nil {
+		return err
+	}
+	for i, f := range dt.fields {
+		encoded, err := dt.encodeBytes(dt.records[row][i])
+		if err != nil {
+			return fmt.Errorf("dbf: encoding record %d field %d: %w", row, i, err)
+		}
+		if _, err := w.Write(formatField(string(encoded), f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatField renders value into the fixed-width, space-padded byte layout
+// dBASE expects for f, truncating values that don't fit.
+func formatField(value string, f FieldDescriptor) []byte {
+	buf := make([]byte, f.Length)
+	for i := range buf {
+		buf[i] = ' '
+	}
+	if len(value) > f.Length {
+		value = value[:f.Length]
+	}
+	switch f.Type {
+	case Character, Date:
+		copy(buf, value)
+	default: // Number, Float, Logical: right-justified
+		copy(buf[f.Length-len(value):], value)
+	}
+	return buf
+}
+
+// LoadFile reads a dBASE III table from fileName. If fileName has a
+// non-empty WAL sidecar left over from an interrupted Update, it's replayed
+// or discarded before the file is read; see recoverWAL. Any "<fileName>.*.idx"
+// sidecar indexes are loaded too, under the name between the two dots.
+func LoadFile(fileName string) (*DbfTable, error) {
+	if err := recoverWAL(fileName); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dt, err := decode(f)
+	if err != nil {
+		return nil, err
+	}
+	dt.path = fileName
+	if err := dt.loadIndexes(); err != nil {
+		return nil, err
+	}
+	return dt, nil
+}
+
+// decode reads a dBASE III table from r.
+func decode(r io.Reader) (*DbfTable, error) {
+	br := bufio.NewReader(r)
+
+	var header [headerSize]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("dbf: reading header: %w", err)
+	}
+	numRecords := binary.LittleEndian.Uint32(header[4:8])
+	headerLen := binary.LittleEndian.Uint16(header[8:10])
+
+	numFields := (int(headerLen) - headerSize - 1) / fieldDescSize
+
+	dt := New()
+	dt.modified = time.Date(1900+int(header[1]), time.Month(header[2]), int(header[3]), 0, 0, 0, 0, time.UTC)
+	if enc, ok := ldidRegistry[header[29]]; ok {
+		dt.encoding = enc
+	}
+
+	for i := 0; i < numFields; i++ {
+		var desc [fieldDescSize]byte
+		if _, err := io.ReadFull(br, desc[:]); err != nil {
+			return nil, fmt.Errorf("dbf: reading field descriptor %d: %w", i, err)
+		}
+		name := strings.TrimRight(string(desc[0:fieldNameSize]), "\x00")
+		dt.addField(name, desc[11], int(desc[16]), int(desc[17]))
+	}
+	if _, err := br.ReadByte(); err != nil { // field array terminator
+		return nil, fmt.Errorf("dbf: reading field terminator: %w", err)
+	}
+
+	recordSize := 1
+	for _, f := range dt.fields {
+		recordSize += f.Length
+	}
+
+	for i := uint32(0); i < numRecords; i++ {
+		buf := make([]byte, recordSize)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, fmt.Errorf("dbf: reading record %d: %w", i, err)
+		}
+		row := dt.AddRecord()
+		if buf[0] == deletedFlag {
+			dt.deleted[row] = true
+		}
+		offset := 1
+		for fi, f := range dt.fields {
+			value, err := dt.decodeBytes(buf[offset : offset+f.Length])
+			if err != nil {
+				return nil, fmt.Errorf("dbf: decoding record %d field %d: %w", i, fi, err)
+			}
+			dt.records[row][fi] = strings.TrimSpace(value)
+			offset += f.Length
+		}
+	}
+
+	return dt, nil
+}
@@ -1,9 +1,16 @@
 package dbf
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"golang.org/x/text/encoding/charmap"
 )
 
 const tempdbf = "temp.dbf"
@@ -225,6 +232,582 @@ func TestOmitEmpty(t *testing.T) {
 	}
 }
 
+func TestUpdateCommitsToWAL(t *testing.T) {
+	temp, err := os.CreateTemp("", "test_dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := temp.Name()
+	temp.Close()
+	defer os.Remove(path)
+	defer os.Remove(path + ".wal")
+
+	db := New()
+	db.AddTextField("name", 40)
+	if err := db.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *Tx) error {
+		row, err := tx.AddRecord()
+		if err != nil {
+			return err
+		}
+		return tx.SetFieldValueByName(row, "name", "hello")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(path + ".wal"); err != nil {
+		t.Fatal(err)
+	} else if info.Size() != 0 {
+		t.Fatal("WAL should be truncated to empty after a successful commit")
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCount(t, loaded, 1)
+	if got := loaded.FieldValueByName(0, "name"); got != "hello" {
+		t.Fatalf("expected name %q, got %q", "hello", got)
+	}
+}
+
+func TestUpdateRollsBackOnError(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+	db.AddRecord()
+
+	wantErr := fmt.Errorf("boom")
+	err := db.Update(func(tx *Tx) error {
+		if _, err := tx.AddRecord(); err != nil {
+			return err
+		}
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	checkCount(t, db, 1)
+}
+
+func TestViewRejectsMutation(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+	db.AddRecord()
+
+	err := db.View(func(tx *Tx) error {
+		_, err := tx.AddRecord()
+		return err
+	})
+	if err == nil {
+		t.Fatal("expected View to reject a mutation")
+	}
+	checkCount(t, db, 1)
+}
+
+// TestConcurrentUpdatesDontClobber runs many concurrent Updates against one
+// table, some of which fail and roll back, and checks that every committed
+// record survives: a failing transaction's rollback must never restore a
+// snapshot taken before a different, already-committed transaction's write.
+func TestConcurrentUpdatesDontClobber(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := db.Update(func(tx *Tx) error {
+				row, err := tx.AddRecord()
+				if err != nil {
+					return err
+				}
+				if err := tx.SetFieldValueByName(row, "name", fmt.Sprintf("rec%d", i)); err != nil {
+					return err
+				}
+				if i%2 == 0 {
+					return fmt.Errorf("rolling back rec%d", i)
+				}
+				return nil
+			})
+			if i%2 == 0 && err == nil {
+				t.Errorf("rec%d: expected rollback error, got nil", i)
+			}
+			if i%2 == 1 && err != nil {
+				t.Errorf("rec%d: expected commit, got %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := db.NumRecords(), n/2; got != want {
+		t.Fatalf("NumRecords() = %d, want %d (one per committed transaction)", got, want)
+	}
+}
+
+// TestUpdateIsolatesUncommittedWrites checks that a direct read racing an
+// in-flight Update never observes that Update's write before it commits,
+// and never observes it at all if the Update rolls back: Update stages fn's
+// writes in its Tx and only applies them to the table in one step, so
+// there's no window where a partial transaction is visible.
+func TestUpdateIsolatesUncommittedWrites(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+	row := db.AddRecord()
+	db.SetFieldValueByName(row, "name", "before")
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := db.Update(func(tx *Tx) error {
+			if err := tx.SetFieldValueByName(row, "name", "during"); err != nil {
+				return err
+			}
+			close(started)
+			<-release
+			return fmt.Errorf("rolling back")
+		})
+		if err == nil {
+			t.Error("expected Update to roll back")
+		}
+	}()
+
+	<-started
+	if got := db.FieldValueByName(row, "name"); got != "before" {
+		t.Errorf("read during in-flight Update = %q, want %q (dirty read)", got, "before")
+	}
+	close(release)
+	wg.Wait()
+
+	if got := db.FieldValueByName(row, "name"); got != "before" {
+		t.Fatalf("read after rollback = %q, want %q", got, "before")
+	}
+}
+
+func TestIndexLookupAndRange(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+	db.AddIntField("age", 10)
+
+	for _, rec := range []struct {
+		name string
+		age  string
+	}{
+		{"carol", "40"},
+		{"alice", "30"},
+		{"bob", "30"},
+	} {
+		row := db.AddRecord()
+		db.SetFieldValueByName(row, "name", rec.name)
+		db.SetFieldValueByName(row, "age", rec.age)
+	}
+
+	idx, err := db.CreateIndex("by_name", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := idx.Lookup("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || db.FieldValueByName(rows[0], "name") != "bob" {
+		t.Fatalf("Lookup(bob) = %v, want the bob row", rows)
+	}
+
+	var names []string
+	it := db.NewIterator(idx)
+	for it.Next() {
+		names = append(names, it.Values()[0])
+	}
+	want := []string{"alice", "bob", "carol"}
+	if len(names) != len(want) {
+		t.Fatalf("got %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("got %v, want %v", names, want)
+		}
+	}
+
+	rangeIt := idx.Range([]interface{}{"a"}, []interface{}{"b"})
+	var ranged []string
+	for rangeIt.Next() {
+		ranged = append(ranged, rangeIt.Values()[0])
+	}
+	if len(ranged) != 2 || ranged[0] != "alice" || ranged[1] != "bob" {
+		t.Fatalf("Range(a, b) = %v, want [alice bob]", ranged)
+	}
+}
+
+func TestIndexLookupSkipsDeletedRows(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+
+	row := db.AddRecord()
+	db.SetFieldValueByName(row, "name", "bob")
+	db.Delete(row)
+
+	idx, err := db.CreateIndex("by_name", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := idx.Lookup("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Fatalf("Lookup(bob) = %v, want no rows: bob's only row is deleted", rows)
+	}
+}
+
+func TestIndexPersistsAndReloads(t *testing.T) {
+	temp, err := os.CreateTemp("", "test_dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := temp.Name()
+	temp.Close()
+	defer os.Remove(path)
+	defer os.Remove(path + ".by_name.idx")
+
+	db := New()
+	db.AddTextField("name", 40)
+	db.AddRecord()
+	db.SetFieldValueByName(0, "name", "alice")
+	if err := db.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateIndex("by_name", "name"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := loaded.Index("by_name")
+	if idx == nil {
+		t.Fatal("expected by_name index to be loaded from its sidecar file")
+	}
+	rows, err := idx.Lookup("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0] != 0 {
+		t.Fatalf("Lookup(alice) = %v, want [0]", rows)
+	}
+}
+
+// TestConcurrentCreateIndexAndIndex exercises CreateIndex racing Index
+// under the race detector: both touch dt.indexes, so every access to that
+// map must go through dt.mu.
+func TestConcurrentCreateIndexAndIndex(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+	db.AddRecord()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := db.CreateIndex(fmt.Sprintf("idx%d", i), "name"); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			db.Index(fmt.Sprintf("idx%d", i))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMemoAndBlobFields(t *testing.T) {
+	temp, err := os.CreateTemp("", "test_dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := temp.Name()
+	temp.Close()
+	defer os.Remove(path)
+
+	db := New()
+	db.AddTextField("name", 40)
+	db.AddMemoField("notes")
+	db.AddBlobField("data")
+	if err := db.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(memoPath(path))
+
+	row := db.AddRecord()
+	db.SetFieldValueByName(row, "name", "alice")
+	longText := strings.Repeat("hello world ", 100)
+	if err := db.SetMemoValueByName(row, "notes", longText); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetBlobValueByName(row, "data", []byte{0x00, 0x1A, 0xFF, 0x01}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := loaded.MemoValueByName(row, "notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != longText {
+		t.Fatalf("MemoValueByName = %q, want %q", text, longText)
+	}
+	blob, err := loaded.BlobValueByName(row, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(blob, []byte{0x00, 0x1A, 0xFF, 0x01}) {
+		t.Fatalf("BlobValueByName = %v, want %v", blob, []byte{0x00, 0x1A, 0xFF, 0x01})
+	}
+}
+
+// TestConcurrentMemoWritesDontCorrupt runs many concurrent SetMemoValue
+// calls against one table's .dbt file under the race detector and checks
+// every value round-trips: appendMemoBlock's header read and its two
+// writes aren't atomic on their own, so they need memoMu to keep two
+// concurrent appends from landing on the same block.
+func TestConcurrentMemoWritesDontCorrupt(t *testing.T) {
+	temp, err := os.CreateTemp("", "test_dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := temp.Name()
+	temp.Close()
+	defer os.Remove(path)
+
+	db := New()
+	db.AddTextField("name", 40)
+	db.AddMemoField("notes")
+	if err := db.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(memoPath(path))
+
+	const n = 20
+	rows := make([]int, n)
+	for i := range rows {
+		rows[i] = db.AddRecord()
+	}
+
+	var wg sync.WaitGroup
+	for i, row := range rows {
+		i, row := i, row
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.SetMemoValueByName(row, "notes", fmt.Sprintf("note%d", i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, row := range rows {
+		got, err := db.MemoValueByName(row, "notes")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("note%d", i); got != want {
+			t.Fatalf("row %d: MemoValueByName = %q, want %q", row, got, want)
+		}
+	}
+}
+
+func TestCreateWithMemoTag(t *testing.T) {
+	temp, err := os.CreateTemp("", "test_dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := temp.Name()
+	temp.Close()
+	defer os.Remove(path)
+	defer os.Remove(memoPath(path))
+
+	type Doc struct {
+		Title string `dbf:"TITLE"`
+		Body  string `dbf:"BODY,memo"`
+		Blob  []byte `dbf:"BLOB"`
+	}
+
+	db := New()
+	if err := db.Create(Doc{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	longBody := strings.Repeat("x", 1000)
+	row, err := db.Append(Doc{Title: "t", Body: longBody, Blob: []byte{1, 2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Doc
+	if err := db.Read(row, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "t" || got.Body != longBody || !bytes.Equal(got.Blob, []byte{1, 2, 3}) {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	temp, err := os.CreateTemp("", "test_dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := temp.Name()
+	temp.Close()
+	defer os.Remove(path)
+
+	db := New()
+	db.AddTextField("name", 40)
+	db.SetEncoding(charmap.Windows1251) // Russian Windows (cp1251)
+	row := db.AddRecord()
+	db.SetFieldValueByName(row, "name", "Привет")
+
+	if err := db.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw[29] != ldidFor(charmap.Windows1251) {
+		t.Fatalf("header LDID byte = %#x, want %#x", raw[29], ldidFor(charmap.Windows1251))
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.FieldValueByName(0, "name"); got != "Привет" {
+		t.Fatalf("FieldValueByName = %q, want %q", got, "Привет")
+	}
+}
+
+// TestLdidForIsDeterministic guards against ldidFor reverse-scanning a map
+// with duplicate values: several LDIDs alias the same encoding.Encoding
+// (e.g. 0x02 and 0x37 both mean CodePage850), so a naive map scan would
+// return a different LDID from run to run.
+func TestLdidForIsDeterministic(t *testing.T) {
+	want := ldidFor(charmap.CodePage850)
+	for i := 0; i < 100; i++ {
+		if got := ldidFor(charmap.CodePage850); got != want {
+			t.Fatalf("ldidFor(CodePage850) = %#x on call %d, want %#x", got, i, want)
+		}
+	}
+}
+
+// TestConcurrentSetEncodingAndEncodeTo exercises SetEncoding racing
+// encodeTo (the core of SaveFile) under the race detector: both touch
+// dt.encoding and dt.invalidMode, so SetEncoding must take dt.mu like every
+// other mutating method.
+func TestConcurrentSetEncodingAndEncodeTo(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+	db.AddRecord()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			db.SetEncoding(charmap.Windows1251)
+		}()
+		go func() {
+			defer wg.Done()
+			if err := db.encodeTo(io.Discard); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+	row := db.AddRecord()
+	db.SetFieldValueByName(row, "name", "alice")
+
+	clone := db.Clone()
+
+	db.SetFieldValueByName(row, "name", "bob")
+	db.AddRecord()
+
+	if got := clone.FieldValueByName(row, "name"); got != "alice" {
+		t.Fatalf("clone.FieldValueByName = %q, want %q", got, "alice")
+	}
+	if clone.NumRecords() != 1 {
+		t.Fatalf("clone.NumRecords() = %d, want 1", clone.NumRecords())
+	}
+	if db.NumRecords() != 2 {
+		t.Fatalf("db.NumRecords() = %d, want 2", db.NumRecords())
+	}
+}
+
+// TestIteratorSnapshotsRowSet exercises the race NewIterator's snapshot
+// exists to avoid: appending or deleting rows concurrently with a running
+// iteration must not change which rows that iteration visits.
+func TestIteratorSnapshotsRowSet(t *testing.T) {
+	db := New()
+	db.AddTextField("name", 40)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		row := db.AddRecord()
+		db.SetFieldValueByName(row, "name", name)
+	}
+
+	it := db.NewIterator()
+
+	db.AddRecord()
+	db.Delete(1)
+
+	var names []string
+	for it.Next() {
+		names = append(names, it.Values()[0])
+	}
+
+	want := []string{"alice", "bob", "carol"}
+	if len(names) != len(want) {
+		t.Fatalf("got %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("got %v, want %v", names, want)
+		}
+	}
+}
+
 func checkCount(t *testing.T, db *DbfTable, count int) {
 	c := 0
 	iter := db.NewIterator()
@@ -0,0 +1,129 @@
+package dbf
+
+import (
+	"fmt"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// InvalidEncodingMode selects what happens when a value can't be
+// represented in a DbfTable's encoding.
+type InvalidEncodingMode int
+
+const (
+	// ReplaceInvalid substitutes the encoding's replacement character for
+	// runes it can't represent, by wrapping the encoder with
+	// encoding.ReplaceUnsupported.
+	ReplaceInvalid InvalidEncodingMode = iota
+	// ErrorOnInvalid makes SaveFile (and an Update's commit) return an error
+	// instead. SetFieldValue* stores the value unchecked.
+	ErrorOnInvalid
+)
+
+// ldidRegistry maps the dBASE header's language driver ID byte (offset 29)
+// to the code page it identifies, covering the legacy single- and
+// double-byte code pages dBASE/FoxPro/Clipper files are commonly found in.
+// LoadFile falls back to encoding.Nop (bytes passed through unchanged) for
+// any LDID this registry doesn't recognize.
+var ldidRegistry = map[byte]encoding.Encoding{
+	0x00: encoding.Nop,
+	0x01: charmap.CodePage437, // U.S. MS-DOS
+	0x02: charmap.CodePage850, // International MS-DOS
+	0x03: charmap.Windows1252, // Windows ANSI (cp1252)
+	0x04: charmap.CodePage852, // Eastern European MS-DOS
+	0x13: charmap.CodePage852,
+	0x26: charmap.CodePage866, // Russian MS-DOS (cp866)
+	0x37: charmap.CodePage850,
+	0x57: charmap.Windows1252,
+	0x7A: simplifiedchinese.GB18030, // Chinese GBK/GB18030
+	0xC8: charmap.Windows1250,       // Eastern European Windows
+	0xC9: charmap.Windows1251,       // Russian Windows
+	0xCA: charmap.Windows1254,
+	0xCB: charmap.Windows1253, // Greek Windows
+}
+
+// ldidForEncoding maps an encoding.Encoding back to the LDID byte SaveFile
+// writes for it. Several LDIDs alias the same code page (e.g. 0x02 and 0x37
+// both mean CodePage850), so this is kept as its own explicit, one-to-one
+// table rather than derived by reverse-scanning ldidRegistry, which would
+// pick whichever alias Go's randomized map iteration happened to land on
+// first.
+var ldidForEncoding = map[encoding.Encoding]byte{
+	encoding.Nop:              0x00,
+	charmap.CodePage437:       0x01,
+	charmap.CodePage850:       0x02,
+	charmap.Windows1252:       0x03,
+	charmap.CodePage852:       0x04,
+	charmap.CodePage866:       0x26,
+	simplifiedchinese.GB18030: 0x7A,
+	charmap.Windows1250:       0xC8,
+	charmap.Windows1251:       0xC9,
+	charmap.Windows1254:       0xCA,
+	charmap.Windows1253:       0xCB,
+}
+
+// ldidFor returns the LDID byte that identifies enc, or 0x00 (no code page)
+// if enc isn't in ldidForEncoding.
+func ldidFor(enc encoding.Encoding) byte {
+	if ldid, ok := ldidForEncoding[enc]; ok {
+		return ldid
+	}
+	return 0x00
+}
+
+// SetEncoding sets the code page dt's field values are transcoded to from
+// UTF-8 when SaveFile writes them (SetFieldValue*/FieldValue* always deal in
+// UTF-8 strings), and the language driver byte SaveFile writes to the
+// header. mode controls what happens when a value can't be represented in
+// enc; it defaults to ReplaceInvalid.
+//
+// LoadFile sets the encoding automatically, detecting it from the header's
+// LDID byte and decoding field bytes to UTF-8 as it reads them, so this only
+// needs to be called directly to override that guess or to pick an encoding
+// for a table built with New.
+func (dt *DbfTable) SetEncoding(enc encoding.Encoding, mode ...InvalidEncodingMode) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	dt.encoding = enc
+	dt.invalidMode = ReplaceInvalid
+	if len(mode) > 0 {
+		dt.invalidMode = mode[0]
+	}
+}
+
+// resolvedEncoding is an internal helper for encodeTo/decode, which already
+// hold dt.mu (or, for decode, own dt outright before it's shared) for the
+// whole call; it doesn't lock itself to avoid recursive RLock, which is
+// unsafe once a writer is waiting.
+func (dt *DbfTable) resolvedEncoding() encoding.Encoding {
+	if dt.encoding == nil {
+		return encoding.Nop
+	}
+	return dt.encoding
+}
+
+// decodeBytes transcodes raw on-disk field bytes, in dt's code page, to a
+// UTF-8 Go string.
+func (dt *DbfTable) decodeBytes(b []byte) (string, error) {
+	out, err := dt.resolvedEncoding().NewDecoder().Bytes(b)
+	if err != nil {
+		return "", fmt.Errorf("dbf: decoding field bytes: %w", err)
+	}
+	return string(out), nil
+}
+
+// encodeBytes transcodes a UTF-8 field value to dt's code page, for
+// writing to disk.
+func (dt *DbfTable) encodeBytes(s string) ([]byte, error) {
+	enc := dt.resolvedEncoding().NewEncoder()
+	if dt.invalidMode == ReplaceInvalid {
+		enc = encoding.ReplaceUnsupported(enc)
+	}
+	out, err := enc.Bytes([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("dbf: value %q is not representable in the table's encoding: %w", s, err)
+	}
+	return out, nil
+}
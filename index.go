@@ -0,0 +1,333 @@
+package dbf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// idxMagic identifies an index sidecar file.
+const idxMagic = "IDX1"
+
+// Index is a secondary index over one or more fields of a DbfTable, keeping
+// row numbers in key order so Lookup and Range run in O(log N) instead of a
+// full-table SetFieldValueByName scan. It's a point-in-time snapshot built
+// by CreateIndex (or loaded from its sidecar file by LoadFile); call
+// Refresh to bring it back in sync after mutating the table.
+//
+// Indexes persist next to their table as "<table>.<name>.idx": a flat,
+// sorted array of composite keys and row numbers, in a format specific to
+// this package. dbf only speaks the dBASE III table format, not the B-tree
+// page layout real dBASE/FoxPro/Clipper .NDX/.CDX files use on disk, so this
+// is deliberately a from-scratch format under its own ".idx" name rather
+// than a byte-compatible one — legacy dBASE tools can't read it, but
+// LoadFile/CreateIndex round-trip it fine. If byte-compatible .NDX/.CDX
+// interop is needed, that's a separate, much larger format to implement and
+// should be scoped as its own request.
+type Index struct {
+	table  *DbfTable
+	name   string
+	fields []int
+	path   string // sidecar file path, or "" if the table has no path
+
+	entries []indexEntry // sorted by key
+}
+
+type indexEntry struct {
+	key string
+	row int
+}
+
+// CreateIndex builds an index named name over fields, in the order given,
+// and persists it next to dt's file if dt has one (see DbfTable.SaveFile).
+// Field names are matched the same way SetFieldValueByName resolves one.
+func (dt *DbfTable) CreateIndex(name string, fields ...string) (*Index, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("dbf: CreateIndex %q: at least one field required", name)
+	}
+
+	fieldIdx := make([]int, len(fields))
+	for i, f := range fields {
+		idx := dt.FieldIndex(f)
+		if idx < 0 {
+			return nil, fmt.Errorf("dbf: CreateIndex %q: unknown field %q", name, f)
+		}
+		fieldIdx[i] = idx
+	}
+
+	idx := &Index{table: dt, name: name, fields: fieldIdx}
+	idx.build()
+
+	dt.mu.RLock()
+	path := dt.path
+	dt.mu.RUnlock()
+	if path != "" {
+		idx.path = indexPath(path, name)
+		if err := idx.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	dt.mu.Lock()
+	if dt.indexes == nil {
+		dt.indexes = make(map[string]*Index)
+	}
+	dt.indexes[name] = idx
+	dt.mu.Unlock()
+	return idx, nil
+}
+
+// Index returns the named index created with CreateIndex or loaded from a
+// sidecar file by LoadFile, or nil if there is none.
+func (dt *DbfTable) Index(name string) *Index {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	return dt.indexes[name]
+}
+
+func indexPath(tablePath, name string) string {
+	return tablePath + "." + name + ".idx"
+}
+
+// Refresh rebuilds idx from the table's current contents and, if idx has a
+// sidecar file, rewrites it. Call this after mutating indexed fields;
+// CreateIndex and LoadFile produce a snapshot that doesn't update itself.
+func (idx *Index) Refresh() error {
+	idx.build()
+	if idx.path == "" {
+		return nil
+	}
+	return idx.save()
+}
+
+// build (re)populates idx.entries by scanning every row of the table,
+// including deleted ones, and sorting by key.
+func (idx *Index) build() {
+	entries := make([]indexEntry, idx.table.NumRecords())
+	for row := range entries {
+		entries[row] = indexEntry{key: idx.key(row), row: row}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	idx.entries = entries
+}
+
+// key builds row's composite sort key from idx's fields.
+func (idx *Index) key(row int) string {
+	parts := make([]string, len(idx.fields))
+	for i, f := range idx.fields {
+		parts[i] = idx.table.FieldValue(row, f)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// searchKey builds the composite key to search for from caller-supplied
+// values, formatted the same way FieldValue values compare.
+func searchKey(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// Lookup returns the row numbers, in ascending order, whose indexed fields
+// equal key. key must have one value per field idx was created with.
+// Deleted rows are skipped, the same as NewIterator and Range.
+func (idx *Index) Lookup(key ...interface{}) ([]int, error) {
+	if len(key) != len(idx.fields) {
+		return nil, fmt.Errorf("dbf: index %q: got %d key parts, want %d", idx.name, len(key), len(idx.fields))
+	}
+
+	target := searchKey(key)
+	lo := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key >= target })
+
+	var rows []int
+	for i := lo; i < len(idx.entries) && idx.entries[i].key == target; i++ {
+		row := idx.entries[i].row
+		if idx.table.IsDeleted(row) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+	return rows, nil
+}
+
+// Range returns an Iterator over the records whose indexed fields fall
+// between low and high inclusive, visited in key order. Either bound may be
+// shorter than idx's field list (or nil/empty) for an open-ended or
+// prefix-only scan, e.g. Range([]interface{}{"smith"}, []interface{}{"smith"})
+// on a (last, first) index visits every "smith", while a nil high bound runs
+// on to the end of the index.
+func (idx *Index) Range(low, high []interface{}) *Iterator {
+	lo := 0
+	if len(low) > 0 {
+		loKey := searchKey(low)
+		lo = sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key >= loKey })
+	}
+
+	hi := len(idx.entries)
+	if len(high) > 0 {
+		// \xff sorts after any field separator, so this includes every key
+		// that merely starts with the given prefix.
+		hiKey := searchKey(high) + "\xff"
+		hi = sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key >= hiKey })
+	}
+
+	order := make([]int, 0, hi-lo)
+	for i := lo; i < hi; i++ {
+		order = append(order, idx.entries[i].row)
+	}
+
+	it := idx.table.NewIterator()
+	it.order = order
+	it.pos = -1
+	return it
+}
+
+// rows returns the row numbers of idx's entries, in key order.
+func (idx *Index) rows() []int {
+	order := make([]int, len(idx.entries))
+	for i, e := range idx.entries {
+		order[i] = e.row
+	}
+	return order
+}
+
+// save writes idx to its sidecar file.
+func (idx *Index) save() error {
+	f, err := os.Create(idx.path)
+	if err != nil {
+		return fmt.Errorf("dbf: writing index %q: %w", idx.name, err)
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+
+	if _, err := bw.WriteString(idxMagic); err != nil {
+		return err
+	}
+
+	tableFields := idx.table.Fields()
+	fieldNames := make([]string, len(idx.fields))
+	for i, f := range idx.fields {
+		fieldNames[i] = tableFields[f].Name
+	}
+	if err := writeIdxString(bw, strings.Join(fieldNames, ",")); err != nil {
+		return err
+	}
+
+	var count [4]byte
+	binary.LittleEndian.PutUint32(count[:], uint32(len(idx.entries)))
+	if _, err := bw.Write(count[:]); err != nil {
+		return err
+	}
+	for _, e := range idx.entries {
+		if err := writeIdxString(bw, e.key); err != nil {
+			return err
+		}
+		var row [4]byte
+		binary.LittleEndian.PutUint32(row[:], uint32(e.row))
+		if _, err := bw.Write(row[:]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// loadIndexes scans for "<dt.path>.*.idx" sidecar files and attaches each
+// one it finds to dt, under the name between the two dots.
+func (dt *DbfTable) loadIndexes() error {
+	matches, err := filepath.Glob(dt.path + ".*.idx")
+	if err != nil {
+		return fmt.Errorf("dbf: globbing indexes: %w", err)
+	}
+	for _, m := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(m, dt.path+"."), ".idx")
+		idx, err := loadIndex(dt, name, m)
+		if err != nil {
+			return err
+		}
+		dt.mu.Lock()
+		if dt.indexes == nil {
+			dt.indexes = make(map[string]*Index)
+		}
+		dt.indexes[name] = idx
+		dt.mu.Unlock()
+	}
+	return nil
+}
+
+// loadIndex reads an index sidecar file written by (*Index).save.
+func loadIndex(dt *DbfTable, name, path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("dbf: reading index %q: %w", name, err)
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+
+	magic := make([]byte, len(idxMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("dbf: reading index %q: %w", name, err)
+	}
+	if string(magic) != idxMagic {
+		return nil, fmt.Errorf("dbf: index %q: not an index file", name)
+	}
+
+	fieldList, err := readIdxString(br)
+	if err != nil {
+		return nil, fmt.Errorf("dbf: reading index %q: %w", name, err)
+	}
+	fieldNames := strings.Split(fieldList, ",")
+	fields := make([]int, len(fieldNames))
+	for i, fn := range fieldNames {
+		fields[i] = dt.FieldIndex(fn)
+	}
+
+	var count [4]byte
+	if _, err := io.ReadFull(br, count[:]); err != nil {
+		return nil, fmt.Errorf("dbf: reading index %q: %w", name, err)
+	}
+
+	entries := make([]indexEntry, binary.LittleEndian.Uint32(count[:]))
+	for i := range entries {
+		key, err := readIdxString(br)
+		if err != nil {
+			return nil, fmt.Errorf("dbf: reading index %q entry %d: %w", name, i, err)
+		}
+		var row [4]byte
+		if _, err := io.ReadFull(br, row[:]); err != nil {
+			return nil, fmt.Errorf("dbf: reading index %q entry %d: %w", name, i, err)
+		}
+		entries[i] = indexEntry{key: key, row: int(binary.LittleEndian.Uint32(row[:]))}
+	}
+
+	return &Index{table: dt, name: name, fields: fields, path: path, entries: entries}, nil
+}
+
+func writeIdxString(w *bufio.Writer, s string) error {
+	var n [2]byte
+	binary.LittleEndian.PutUint16(n[:], uint16(len(s)))
+	if _, err := w.Write(n[:]); err != nil {
+		return err
+	}
+	_, err := w.WriteString(s)
+	return err
+}
+
+func readIdxString(r *bufio.Reader) (string, error) {
+	var n [2]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return "", err
+	}
+	buf := make([]byte, binary.LittleEndian.Uint16(n[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
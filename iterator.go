@@ -0,0 +1,80 @@
+package dbf
+
+// Iterator walks the live (non-deleted) records of a DbfTable, in row order
+// by default, or in an Index's key order when one is passed to NewIterator
+// or the iterator comes from Index.Range.
+//
+// NewIterator snapshots the record count and deletion flags up front, so a
+// concurrent AddRecord or Delete on the table doesn't change which rows a
+// long-running iteration visits. Values and Read still read the table live,
+// so field values can change mid-iteration even though the row set can't.
+type Iterator struct {
+	table      *DbfTable
+	row        int
+	numRecords int
+	deleted    []bool
+
+	// order, when non-nil, is the sequence of row numbers to walk instead
+	// of physical row order; pos is the cursor into it.
+	order []int
+	pos   int
+}
+
+// NewIterator returns an Iterator positioned before the first record, in
+// physical row order. If idx is given, the iterator walks the table in
+// idx's key order instead.
+func (dt *DbfTable) NewIterator(idx ...*Index) *Iterator {
+	dt.mu.RLock()
+	numRecords := len(dt.records)
+	deleted := make([]bool, len(dt.deleted))
+	copy(deleted, dt.deleted)
+	dt.mu.RUnlock()
+
+	it := &Iterator{table: dt, row: -1, numRecords: numRecords, deleted: deleted}
+	if len(idx) > 0 && idx[0] != nil {
+		it.order = idx[0].rows()
+		it.pos = -1
+	}
+	return it
+}
+
+// Next advances the iterator to the next non-deleted record, skipping over
+// deleted ones. It returns false once there are no more records.
+func (it *Iterator) Next() bool {
+	if it.order != nil {
+		for {
+			it.pos++
+			if it.pos >= len(it.order) {
+				return false
+			}
+			it.row = it.order[it.pos]
+			if it.row < len(it.deleted) && !it.deleted[it.row] {
+				return true
+			}
+		}
+	}
+	for {
+		it.row++
+		if it.row >= it.numRecords {
+			return false
+		}
+		if !it.deleted[it.row] {
+			return true
+		}
+	}
+}
+
+// Row returns the row number the iterator last stopped on.
+func (it *Iterator) Row() int {
+	return it.row
+}
+
+// Values returns a copy of the field values of the current record.
+func (it *Iterator) Values() []string {
+	return it.table.Row(it.row)
+}
+
+// Read decodes the current record into v, the same way DbfTable.Read does.
+func (it *Iterator) Read(v interface{}) error {
+	return it.table.Read(it.row, v)
+}
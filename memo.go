@@ -0,0 +1,204 @@
+package dbf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Memo and General fields store a 10-byte ASCII block number in the
+// record's fixed-width portion, pointing into the table's .dbt sidecar
+// file, the way dBASE III handles memos that don't fit in a 254-byte
+// Character field. General fields reuse the same sidecar and block format
+// for arbitrary binary data rather than text; dbf doesn't distinguish FoxPro
+// .FPT memos/general fields, only the dBASE III .dbt layout.
+const (
+	memoBlockSize  = 512
+	memoTerminator = 0x1A
+)
+
+// AddMemoField adds a Memo ('M') field: a pointer to a block of text kept
+// in the table's .dbt sidecar file, for values longer than a Character
+// field's 254-byte limit. Read and write it with MemoValue/SetMemoValue (or
+// the ByName variants); SetFieldValue/FieldValue only see the raw block
+// pointer.
+func (dt *DbfTable) AddMemoField(name string) int {
+	return dt.addField(name, Memo, 10, 0)
+}
+
+// AddBlobField adds a General ('G') field: a pointer to a block of
+// arbitrary binary data kept in the table's .dbt sidecar file, the same way
+// a Memo field stores text. Read and write it with BlobValue/SetBlobValue
+// (or the ByName variants).
+func (dt *DbfTable) AddBlobField(name string) int {
+	return dt.addField(name, General, 10, 0)
+}
+
+// memoPath returns the .dbt sidecar path for a table stored at path.
+func memoPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".dbt"
+}
+
+// SetMemoValue writes value as row's memo field, appending a new block to
+// the table's .dbt sidecar file (created if it doesn't exist yet) and
+// storing the block number in the field's fixed-width slot. dt must have a
+// path, set by LoadFile or SaveFile.
+func (dt *DbfTable) SetMemoValue(row, field int, value string) error {
+	return dt.setMemoValue(row, field, []byte(value))
+}
+
+// MemoValue returns the text last written to row's memo field with
+// SetMemoValue, or "" if the field is empty.
+func (dt *DbfTable) MemoValue(row, field int) (string, error) {
+	data, err := dt.memoValue(row, field)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SetBlobValue writes value as row's blob field, the same way SetMemoValue
+// stores text.
+func (dt *DbfTable) SetBlobValue(row, field int, value []byte) error {
+	return dt.setMemoValue(row, field, value)
+}
+
+// BlobValue returns the bytes last written to row's blob field with
+// SetBlobValue, or nil if the field is empty.
+func (dt *DbfTable) BlobValue(row, field int) ([]byte, error) {
+	return dt.memoValue(row, field)
+}
+
+// SetMemoValueByName sets the named memo field in row.
+func (dt *DbfTable) SetMemoValueByName(row int, name, value string) error {
+	return dt.SetMemoValue(row, dt.FieldIndex(name), value)
+}
+
+// MemoValueByName returns the named memo field in row.
+func (dt *DbfTable) MemoValueByName(row int, name string) (string, error) {
+	return dt.MemoValue(row, dt.FieldIndex(name))
+}
+
+// SetBlobValueByName sets the named blob field in row.
+func (dt *DbfTable) SetBlobValueByName(row int, name string, value []byte) error {
+	return dt.SetBlobValue(row, dt.FieldIndex(name), value)
+}
+
+// BlobValueByName returns the named blob field in row.
+func (dt *DbfTable) BlobValueByName(row int, name string) ([]byte, error) {
+	return dt.BlobValue(row, dt.FieldIndex(name))
+}
+
+func (dt *DbfTable) setMemoValue(row, field int, value []byte) error {
+	if dt.path == "" {
+		return fmt.Errorf("dbf: table has no path to store its .dbt sidecar")
+	}
+	dt.memoMu.Lock()
+	block, err := appendMemoBlock(memoPath(dt.path), value)
+	dt.memoMu.Unlock()
+	if err != nil {
+		return err
+	}
+	dt.SetFieldValue(row, field, strconv.Itoa(block))
+	return nil
+}
+
+func (dt *DbfTable) memoValue(row, field int) ([]byte, error) {
+	raw := strings.TrimSpace(dt.FieldValue(row, field))
+	if raw == "" {
+		return nil, nil
+	}
+	block, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("dbf: invalid memo block pointer %q: %w", raw, err)
+	}
+	if dt.path == "" {
+		return nil, fmt.Errorf("dbf: table has no path to read its .dbt sidecar")
+	}
+	dt.memoMu.RLock()
+	defer dt.memoMu.RUnlock()
+	return readMemoBlock(memoPath(dt.path), block)
+}
+
+// appendMemoBlock appends value to the next free block(s) of the .dbt file
+// at path (creating it with a header reserving block 0 if it doesn't exist)
+// and returns the block number the caller should store. Each entry is a
+// 4-byte length prefix followed by value and the dBASE III memo
+// end-of-field marker; the length prefix, rather than that marker, is what
+// readMemoBlock trusts, since a General field's value can itself contain
+// the marker byte.
+func appendMemoBlock(path string, value []byte) (int, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("dbf: opening memo file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("dbf: statting memo file: %w", err)
+	}
+
+	nextBlock := 1
+	if info.Size() >= memoBlockSize {
+		var header [4]byte
+		if _, err := f.ReadAt(header[:], 0); err != nil {
+			return 0, fmt.Errorf("dbf: reading memo header: %w", err)
+		}
+		nextBlock = int(binary.LittleEndian.Uint32(header[:]))
+	} else if _, err := f.WriteAt(make([]byte, memoBlockSize), 0); err != nil {
+		return 0, fmt.Errorf("dbf: writing memo header: %w", err)
+	}
+
+	entry := make([]byte, 4+len(value)+2)
+	binary.LittleEndian.PutUint32(entry[0:4], uint32(len(value)))
+	copy(entry[4:], value)
+	entry[len(entry)-2] = memoTerminator
+	entry[len(entry)-1] = memoTerminator
+
+	blocks := (len(entry) + memoBlockSize - 1) / memoBlockSize
+	padded := make([]byte, blocks*memoBlockSize)
+	copy(padded, entry)
+	if _, err := f.WriteAt(padded, int64(nextBlock)*memoBlockSize); err != nil {
+		return 0, fmt.Errorf("dbf: writing memo block: %w", err)
+	}
+
+	var header [4]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(nextBlock+blocks))
+	if _, err := f.WriteAt(header[:], 0); err != nil {
+		return 0, fmt.Errorf("dbf: updating memo header: %w", err)
+	}
+
+	return nextBlock, nil
+}
+
+// readMemoBlock reads the value stored at block in the .dbt file at path,
+// trusting the 4-byte length prefix appendMemoBlock wrote rather than
+// scanning for the memo terminator, so binary General values round-trip
+// even if they contain the terminator byte.
+func readMemoBlock(path string, block int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("dbf: opening memo file: %w", err)
+	}
+	defer f.Close()
+
+	offset := int64(block) * memoBlockSize
+	var length [4]byte
+	if _, err := f.ReadAt(length[:], offset); err != nil {
+		return nil, fmt.Errorf("dbf: reading memo block %d: %w", block, err)
+	}
+	n := int(binary.LittleEndian.Uint32(length[:]))
+
+	value := make([]byte, n)
+	if n > 0 {
+		if _, err := f.ReadAt(value, offset+4); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("dbf: reading memo block %d: %w", block, err)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,79 @@
+package sqldrv
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bowbaq/dbf"
+)
+
+const dateLayout = "20060102"
+
+// toDriverValue converts the string stored in a DbfTable field into the
+// driver.Value Go type appropriate for its dBASE field type.
+func toDriverValue(raw string, f dbf.FieldDescriptor) (driver.Value, error) {
+	raw = strings.TrimSpace(raw)
+	switch f.Type {
+	case dbf.Logical:
+		return raw == "t" || raw == "T", nil
+	case dbf.Number:
+		if raw == "" {
+			return int64(0), nil
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	case dbf.Float:
+		if raw == "" {
+			return float64(0), nil
+		}
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	case dbf.Date:
+		if raw == "" {
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(dateLayout, raw)
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	default:
+		return raw, nil
+	}
+}
+
+// fromDriverValue renders a driver.Value (bool/int64/float64/string/
+// time.Time) into the string representation DbfTable.SetFieldValue expects.
+func fromDriverValue(v interface{}, f dbf.FieldDescriptor) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case bool:
+		if val {
+			return "t", nil
+		}
+		return "f", nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case string:
+		return val, nil
+	case time.Time:
+		if val.IsZero() {
+			return "", nil
+		}
+		return val.Format(dateLayout), nil
+	default:
+		return "", fmt.Errorf("sqldrv: unsupported value %T for field %s", v, f.Name)
+	}
+}
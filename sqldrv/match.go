@@ -0,0 +1,90 @@
+package sqldrv
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Bowbaq/dbf"
+)
+
+// matches reports whether row satisfies every condition in conds, resolving
+// placeholders against args.
+func matches(table *dbf.DbfTable, row int, conds []condition, args []interface{}) (bool, error) {
+	for _, c := range conds {
+		idx := table.FieldIndex(c.column)
+		if idx < 0 {
+			return false, fmt.Errorf("sqldrv: unknown column %q", c.column)
+		}
+		got, err := toDriverValue(table.FieldValue(row, idx), table.Fields()[idx])
+		if err != nil {
+			return false, err
+		}
+		ok, err := compare(got, c.op, c.resolve(args))
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func compare(got interface{}, op string, want interface{}) (bool, error) {
+	if op == "LIKE" {
+		pattern, ok := want.(string)
+		if !ok {
+			return false, fmt.Errorf("sqldrv: LIKE requires a string pattern")
+		}
+		s := fmt.Sprint(got)
+		pattern = strings.ReplaceAll(pattern, "%", "")
+		return strings.Contains(s, pattern), nil
+	}
+
+	gf, gIsNum := toFloat(got)
+	wf, wIsNum := toFloat(want)
+	if gIsNum && wIsNum {
+		switch op {
+		case "=":
+			return gf == wf, nil
+		case "!=":
+			return gf != wf, nil
+		case "<":
+			return gf < wf, nil
+		case "<=":
+			return gf <= wf, nil
+		case ">":
+			return gf > wf, nil
+		case ">=":
+			return gf >= wf, nil
+		}
+	}
+
+	gs, ws := fmt.Sprint(got), fmt.Sprint(want)
+	switch op {
+	case "=":
+		return gs == ws, nil
+	case "!=":
+		return gs != ws, nil
+	case "<":
+		return gs < ws, nil
+	case "<=":
+		return gs <= ws, nil
+	case ">":
+		return gs > ws, nil
+	case ">=":
+		return gs >= ws, nil
+	}
+	return false, fmt.Errorf("sqldrv: unsupported operator %q", op)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,347 @@
+package sqldrv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type stmtKind int
+
+const (
+	stmtSelect stmtKind = iota
+	stmtInsert
+	stmtUpdate
+	stmtDelete
+)
+
+// condition is a single "column op value" predicate. value is either a
+// literal (parsed at prepare time) or a '?' placeholder, represented by
+// placeholder >= 0 (its position in the argument list).
+type condition struct {
+	column      string
+	op          string
+	value       interface{}
+	placeholder int
+}
+
+// query is the parsed form of one of the dialect's four statement kinds.
+type query struct {
+	kind    stmtKind
+	table   string
+	columns []string // SELECT projection, or INSERT column list
+	values  []value  // INSERT VALUES / UPDATE SET values, in column order
+	assign  []string // UPDATE SET column names, parallel to values
+	where   []condition
+	orderBy string
+	desc    bool
+	limit   int // -1 means unbounded
+
+	numInput int
+}
+
+// value is either a literal or a placeholder ('?', recorded by position).
+type value struct {
+	literal     interface{}
+	placeholder int
+}
+
+func (v value) resolve(args []interface{}) interface{} {
+	if v.placeholder >= 0 {
+		return args[v.placeholder]
+	}
+	return v.literal
+}
+
+func (c condition) resolve(args []interface{}) interface{} {
+	if c.placeholder >= 0 {
+		return args[c.placeholder]
+	}
+	return c.value
+}
+
+// parse turns a single SQL statement into a query. It understands only the
+// subset documented in the package doc comment.
+func parse(sql string) (*query, error) {
+	toks := tokenize(sql)
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("sqldrv: empty query")
+	}
+	p := &parser{toks: toks}
+	switch strings.ToUpper(toks[0]) {
+	case "SELECT":
+		return p.parseSelect()
+	case "INSERT":
+		return p.parseInsert()
+	case "UPDATE":
+		return p.parseUpdate()
+	case "DELETE":
+		return p.parseDelete()
+	default:
+		return nil, fmt.Errorf("sqldrv: unsupported statement %q", toks[0])
+	}
+}
+
+type parser struct {
+	toks []string
+	pos  int
+	next int // next placeholder index to assign
+}
+
+func (p *parser) cur() string {
+	if p.pos >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *parser) advance() string {
+	t := p.cur()
+	p.pos++
+	return t
+}
+
+func (p *parser) expect(kw string) error {
+	if !strings.EqualFold(p.cur(), kw) {
+		return fmt.Errorf("sqldrv: expected %q, got %q", kw, p.cur())
+	}
+	p.advance()
+	return nil
+}
+
+func (p *parser) parseSelect() (*query, error) {
+	q := &query{kind: stmtSelect, limit: -1}
+	p.advance() // SELECT
+	for {
+		t := p.advance()
+		if t == "" {
+			return nil, fmt.Errorf("sqldrv: unexpected end of query in SELECT list")
+		}
+		q.columns = append(q.columns, t)
+		if p.cur() == "," {
+			p.advance()
+			continue
+		}
+		break
+	}
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	q.table = p.advance()
+
+	if strings.EqualFold(p.cur(), "WHERE") {
+		p.advance()
+		where, err := p.parseConditions()
+		if err != nil {
+			return nil, err
+		}
+		q.where = where
+	}
+	if strings.EqualFold(p.cur(), "ORDER") {
+		p.advance()
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		q.orderBy = p.advance()
+		if strings.EqualFold(p.cur(), "DESC") {
+			q.desc = true
+			p.advance()
+		} else if strings.EqualFold(p.cur(), "ASC") {
+			p.advance()
+		}
+	}
+	if strings.EqualFold(p.cur(), "LIMIT") {
+		p.advance()
+		n, err := strconv.Atoi(p.advance())
+		if err != nil {
+			return nil, fmt.Errorf("sqldrv: invalid LIMIT: %w", err)
+		}
+		q.limit = n
+	}
+	q.numInput = p.next
+	return q, nil
+}
+
+func (p *parser) parseInsert() (*query, error) {
+	q := &query{kind: stmtInsert}
+	p.advance() // INSERT
+	if err := p.expect("INTO"); err != nil {
+		return nil, err
+	}
+	q.table = p.advance()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for p.cur() != ")" {
+		q.columns = append(q.columns, p.advance())
+		if p.cur() == "," {
+			p.advance()
+		}
+	}
+	p.advance() // )
+	if err := p.expect("VALUES"); err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for p.cur() != ")" {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		q.values = append(q.values, v)
+		if p.cur() == "," {
+			p.advance()
+		}
+	}
+	p.advance() // )
+	if len(q.columns) != len(q.values) {
+		return nil, fmt.Errorf("sqldrv: %d columns but %d values", len(q.columns), len(q.values))
+	}
+	q.numInput = p.next
+	return q, nil
+}
+
+func (p *parser) parseUpdate() (*query, error) {
+	q := &query{kind: stmtUpdate}
+	p.advance() // UPDATE
+	q.table = p.advance()
+	if err := p.expect("SET"); err != nil {
+		return nil, err
+	}
+	for {
+		col := p.advance()
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		q.assign = append(q.assign, col)
+		q.values = append(q.values, v)
+		if p.cur() == "," {
+			p.advance()
+			continue
+		}
+		break
+	}
+	if strings.EqualFold(p.cur(), "WHERE") {
+		p.advance()
+		where, err := p.parseConditions()
+		if err != nil {
+			return nil, err
+		}
+		q.where = where
+	}
+	q.numInput = p.next
+	return q, nil
+}
+
+func (p *parser) parseDelete() (*query, error) {
+	q := &query{kind: stmtDelete}
+	p.advance() // DELETE
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	q.table = p.advance()
+	if strings.EqualFold(p.cur(), "WHERE") {
+		p.advance()
+		where, err := p.parseConditions()
+		if err != nil {
+			return nil, err
+		}
+		q.where = where
+	}
+	q.numInput = p.next
+	return q, nil
+}
+
+func (p *parser) parseConditions() ([]condition, error) {
+	var conds []condition
+	for {
+		col := p.advance()
+		op := p.advance()
+		switch op {
+		case "=", "!=", "<", "<=", ">", ">=":
+		default:
+			if strings.EqualFold(op, "LIKE") {
+				op = "LIKE"
+			} else {
+				return nil, fmt.Errorf("sqldrv: unsupported operator %q", op)
+			}
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, condition{column: col, op: op, value: v.literal, placeholder: v.placeholder})
+		if strings.EqualFold(p.cur(), "AND") {
+			p.advance()
+			continue
+		}
+		break
+	}
+	return conds, nil
+}
+
+func (p *parser) parseValue() (value, error) {
+	t := p.advance()
+	if t == "?" {
+		v := value{placeholder: p.next}
+		p.next++
+		return v, nil
+	}
+	if len(t) >= 2 && t[0] == '\'' && t[len(t)-1] == '\'' {
+		return value{literal: t[1 : len(t)-1], placeholder: -1}, nil
+	}
+	if n, err := strconv.ParseFloat(t, 64); err == nil {
+		return value{literal: n, placeholder: -1}, nil
+	}
+	if strings.EqualFold(t, "true") || strings.EqualFold(t, "false") {
+		return value{literal: strings.EqualFold(t, "true"), placeholder: -1}, nil
+	}
+	if t == "" {
+		return value{}, fmt.Errorf("sqldrv: expected a value")
+	}
+	return value{literal: t, placeholder: -1}, nil
+}
+
+// tokenize splits sql into keywords, identifiers, quoted strings, numbers
+// and the punctuation this dialect needs ( , ( ) = ! < > ? ).
+func tokenize(sql string) []string {
+	var toks []string
+	r := []rune(sql)
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		case c == '\'':
+			j := i + 1
+			for j < len(r) && r[j] != '\'' {
+				j++
+			}
+			toks = append(toks, string(r[i:j+1]))
+			i = j
+		case c == ',' || c == '(' || c == ')' || c == '?':
+			toks = append(toks, string(c))
+		case c == '=' || c == '<' || c == '>' || c == '!':
+			j := i + 1
+			if j < len(r) && r[j] == '=' {
+				j++
+			}
+			toks = append(toks, string(r[i:j]))
+			i = j - 1
+		default:
+			j := i
+			for j < len(r) && !strings.ContainsRune(" \t\n\r,()=<>!?'", r[j]) {
+				j++
+			}
+			toks = append(toks, string(r[i:j]))
+			i = j - 1
+		}
+	}
+	return toks
+}
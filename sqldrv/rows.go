@@ -0,0 +1,48 @@
+package sqldrv
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+
+	"github.com/Bowbaq/dbf"
+)
+
+// Rows is a snapshot of the row numbers matched by a SELECT, taken at Query
+// time. DBF field values are read lazily as the caller scans.
+type Rows struct {
+	ctx     context.Context
+	table   *dbf.DbfTable
+	columns []string
+	colIdx  []int
+	rows    []int
+	pos     int
+}
+
+// Columns returns the projected column names, in SELECT order.
+func (r *Rows) Columns() []string { return r.columns }
+
+// Close is a no-op: Rows holds no resources beyond the shared Conn.
+func (r *Rows) Close() error { return nil }
+
+// Next fills dest with the next matched row's values, or returns io.EOF once
+// exhausted. It also returns ctx.Err() if the query's context was canceled
+// mid-scan.
+func (r *Rows) Next(dest []driver.Value) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	row := r.rows[r.pos]
+	r.pos++
+	for i, idx := range r.colIdx {
+		v, err := toDriverValue(r.table.FieldValue(row, idx), r.table.Fields()[idx])
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	return nil
+}
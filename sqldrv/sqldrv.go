@@ -0,0 +1,63 @@
+// Package sqldrv adapts dbf.DbfTable to database/sql/driver, so a .dbf file
+// can be opened with sql.Open("dbf", "path/to/file.dbf") and queried with a
+// small SQL dialect: SELECT (with WHERE/ORDER BY/LIMIT), INSERT, UPDATE and
+// DELETE. The "table name" in FROM/INTO/UPDATE clauses is not checked — a
+// DSN addresses exactly one DBF file, which holds exactly one table.
+package sqldrv
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+
+	"github.com/Bowbaq/dbf"
+)
+
+func init() {
+	sql.Register("dbf", &Driver{})
+}
+
+// Driver implements driver.Driver. The DSN is a filesystem path to a .dbf
+// file.
+type Driver struct{}
+
+// Open opens the DBF file at dsn and returns a Conn over it.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	table, err := dbf.LoadFile(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{path: dsn, table: table}, nil
+}
+
+// Conn is a database/sql/driver.Conn backed by a single in-memory DbfTable.
+// All statements prepared against it share the table and a mutex, so each
+// statement's row scan and writes run against the table as one unit, without
+// another statement's changes interleaving.
+type Conn struct {
+	mu    sync.Mutex
+	path  string
+	table *dbf.DbfTable
+}
+
+// Prepare parses query and returns a Stmt ready to Exec or Query it.
+func (c *Conn) Prepare(query string) (driver.Stmt, error) {
+	q, err := parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return &Stmt{conn: c, query: q}, nil
+}
+
+// Close saves any pending changes back to the DBF file.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.table.SaveFile(c.path)
+}
+
+// Begin is unsupported: every statement commits to the in-memory table
+// immediately, and Close flushes to disk.
+func (c *Conn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
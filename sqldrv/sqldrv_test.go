@@ -0,0 +1,164 @@
+package sqldrv
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/Bowbaq/dbf"
+)
+
+func openTestDB(t *testing.T) (*sql.DB, func()) {
+	temp, err := os.CreateTemp("", "test_sqldrv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := temp.Name()
+	temp.Close()
+
+	table := dbf.New()
+	table.AddTextField("name", 40)
+	table.AddIntField("n", 10)
+	if err := table.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("dbf", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.Remove(path)
+	}
+}
+
+func TestInsertAndSelect(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+
+	if _, err := db.Exec("INSERT INTO t (name, n) VALUES (?, ?)", "alice", 30); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO t (name, n) VALUES (?, ?)", "bob", 25); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query("SELECT name, n FROM t WHERE n > ?", 26)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var name string
+	var n int64
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(&name, &n); err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count != 1 || name != "alice" || n != 30 {
+		t.Fatalf("got name=%q n=%d count=%d, want alice/30/1", name, n, count)
+	}
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+
+	if _, err := db.Exec("INSERT INTO t (name, n) VALUES (?, ?)", "alice", 30); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("UPDATE t SET n = ? WHERE name = ?", 31, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	if err := db.QueryRow("SELECT n FROM t WHERE name = ?", "alice").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 31 {
+		t.Fatalf("n = %d, want 31", n)
+	}
+
+	if _, err := db.Exec("DELETE FROM t WHERE name = ?", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("SELECT n FROM t WHERE name = ?", "alice").Scan(&n); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows after delete, got %v", err)
+	}
+}
+
+// TestOrderByIsNumericForNumberColumns guards against sorting a Number
+// column as raw strings, which would put 10 before 2.
+func TestOrderByIsNumericForNumberColumns(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+
+	for _, n := range []int{9, 10, 2} {
+		if _, err := db.Exec("INSERT INTO t (name, n) VALUES (?, ?)", "x", n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := db.Query("SELECT n FROM t ORDER BY n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []int64
+	for rows.Next() {
+		var n int64
+		if err := rows.Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, n)
+	}
+	want := []int64{2, 9, 10}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestOrderByDescAndLimit(t *testing.T) {
+	db, cleanup := openTestDB(t)
+	defer cleanup()
+
+	for _, n := range []int{1, 2, 3} {
+		if _, err := db.Exec("INSERT INTO t (name, n) VALUES (?, ?)", "x", n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := db.Query("SELECT n FROM t ORDER BY n DESC LIMIT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []int64
+	for rows.Next() {
+		var n int64
+		if err := rows.Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, n)
+	}
+	want := []int64{3, 2}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
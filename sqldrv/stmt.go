@@ -0,0 +1,224 @@
+package sqldrv
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+)
+
+// Stmt is a prepared statement over a Conn's table.
+type Stmt struct {
+	conn  *Conn
+	query *query
+}
+
+// Close is a no-op: statements don't hold any resources beyond the shared
+// Conn.
+func (s *Stmt) Close() error { return nil }
+
+// NumInput returns the number of '?' placeholders in the statement.
+func (s *Stmt) NumInput() int { return s.query.numInput }
+
+// Exec runs an INSERT, UPDATE or DELETE.
+func (s *Stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamed(args))
+}
+
+// Query runs a SELECT.
+func (s *Stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamed(args))
+}
+
+// ExecContext runs an INSERT, UPDATE or DELETE, honoring ctx cancellation
+// while scanning matching rows.
+func (s *Stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	s.conn.mu.Lock()
+	defer s.conn.mu.Unlock()
+
+	vals := namedToArgs(args)
+	table := s.conn.table
+	q := s.query
+
+	switch q.kind {
+	case stmtInsert:
+		row := table.AddRecord()
+		for i, col := range q.columns {
+			idx := table.FieldIndex(col)
+			if idx < 0 {
+				return nil, fmt.Errorf("sqldrv: unknown column %q", col)
+			}
+			str, err := fromDriverValue(q.values[i].resolve(vals), table.Fields()[idx])
+			if err != nil {
+				return nil, err
+			}
+			table.SetFieldValue(row, idx, str)
+		}
+		return driver.RowsAffected(1), nil
+
+	case stmtUpdate:
+		var affected int64
+		for row := 0; row < table.NumRecords(); row++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if table.IsDeleted(row) {
+				continue
+			}
+			ok, err := matches(table, row, q.where, vals)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			for i, col := range q.assign {
+				idx := table.FieldIndex(col)
+				if idx < 0 {
+					return nil, fmt.Errorf("sqldrv: unknown column %q", col)
+				}
+				str, err := fromDriverValue(q.values[i].resolve(vals), table.Fields()[idx])
+				if err != nil {
+					return nil, err
+				}
+				table.SetFieldValue(row, idx, str)
+			}
+			affected++
+		}
+		return driver.RowsAffected(affected), nil
+
+	case stmtDelete:
+		var affected int64
+		for row := 0; row < table.NumRecords(); row++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if table.IsDeleted(row) {
+				continue
+			}
+			ok, err := matches(table, row, q.where, vals)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			table.Delete(row)
+			affected++
+		}
+		return driver.RowsAffected(affected), nil
+
+	default:
+		return nil, fmt.Errorf("sqldrv: %T cannot be used with Exec", q.kind)
+	}
+}
+
+// QueryContext runs a SELECT, honoring ctx cancellation while scanning and
+// while the returned Rows are consumed.
+func (s *Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	s.conn.mu.Lock()
+	defer s.conn.mu.Unlock()
+
+	if s.query.kind != stmtSelect {
+		return nil, fmt.Errorf("sqldrv: only SELECT can be used with Query")
+	}
+	table := s.conn.table
+	q := s.query
+	vals := namedToArgs(args)
+
+	columns := q.columns
+	if len(columns) == 1 && columns[0] == "*" {
+		columns = nil
+		for _, f := range table.Fields() {
+			columns = append(columns, f.Name)
+		}
+	}
+	colIdx := make([]int, len(columns))
+	for i, c := range columns {
+		idx := table.FieldIndex(c)
+		if idx < 0 {
+			return nil, fmt.Errorf("sqldrv: unknown column %q", c)
+		}
+		colIdx[i] = idx
+	}
+
+	var matched []int
+	for row := 0; row < table.NumRecords(); row++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if table.IsDeleted(row) {
+			continue
+		}
+		ok, err := matches(table, row, q.where, vals)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, row)
+		}
+	}
+
+	if q.orderBy != "" {
+		orderIdx := table.FieldIndex(q.orderBy)
+		if orderIdx < 0 {
+			return nil, fmt.Errorf("sqldrv: unknown column %q", q.orderBy)
+		}
+		orderField := table.Fields()[orderIdx]
+
+		var sortErr error
+		sort.SliceStable(matched, func(i, j int) bool {
+			av, err := toDriverValue(table.FieldValue(matched[i], orderIdx), orderField)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			bv, err := toDriverValue(table.FieldValue(matched[j], orderIdx), orderField)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+
+			less := orderLess(av, bv)
+			if q.desc {
+				return orderLess(bv, av)
+			}
+			return less
+		})
+		if sortErr != nil {
+			return nil, sortErr
+		}
+	}
+	if q.limit >= 0 && len(matched) > q.limit {
+		matched = matched[:q.limit]
+	}
+
+	return &Rows{ctx: ctx, table: table, columns: columns, colIdx: colIdx, rows: matched}, nil
+}
+
+// orderLess reports whether a sorts before b for ORDER BY: numerically if
+// both convert to a number (Number/Float columns), lexically otherwise.
+func orderLess(a, b interface{}) bool {
+	if af, aIsNum := toFloat(a); aIsNum {
+		if bf, bIsNum := toFloat(b); bIsNum {
+			return af < bf
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+func valuesToNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func namedToArgs(args []driver.NamedValue) []interface{} {
+	vals := make([]interface{}, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	return vals
+}
@@ -0,0 +1,228 @@
+package dbf
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Row is one record read through a Reader: its deletion flag and field
+// values, in the same column order as the Reader's Fields.
+type Row struct {
+	Deleted bool
+	Values  []string
+
+	fields []FieldDescriptor
+}
+
+// Value returns the value of field i.
+func (r Row) Value(i int) string {
+	return r.Values[i]
+}
+
+// ValueByName returns the value of the named field, or "" if there is no
+// such field.
+func (r Row) ValueByName(name string) string {
+	for i, f := range r.fields {
+		if strings.EqualFold(f.Name, name) {
+			return r.Values[i]
+		}
+	}
+	return ""
+}
+
+// Reader gives random-access, record-at-a-time access to a dBASE III file
+// via io.ReaderAt, so multi-gigabyte files can be scanned without loading
+// every record into memory the way LoadFile does.
+type Reader struct {
+	ra         io.ReaderAt
+	fields     []FieldDescriptor
+	headerSize int
+	recordSize int
+	numRecords int
+}
+
+// NewReader parses the header and field descriptors of ra eagerly; records
+// are only read as Record or Iterate is called.
+func NewReader(ra io.ReaderAt) (*Reader, error) {
+	var header [headerSize]byte
+	if _, err := ra.ReadAt(header[:], 0); err != nil {
+		return nil, fmt.Errorf("dbf: reading header: %w", err)
+	}
+	numRecords := int(binary.LittleEndian.Uint32(header[4:8]))
+	headerLen := int(binary.LittleEndian.Uint16(header[8:10]))
+	numFields := (headerLen - headerSize - 1) / fieldDescSize
+
+	r := &Reader{ra: ra, headerSize: headerLen, numRecords: numRecords}
+	descs := make([]byte, numFields*fieldDescSize)
+	if _, err := ra.ReadAt(descs, headerSize); err != nil {
+		return nil, fmt.Errorf("dbf: reading field descriptors: %w", err)
+	}
+	for i := 0; i < numFields; i++ {
+		desc := descs[i*fieldDescSize : (i+1)*fieldDescSize]
+		name := strings.TrimRight(string(desc[0:fieldNameSize]), "\x00")
+		r.fields = append(r.fields, FieldDescriptor{Name: name, Type: desc[11], Length: int(desc[16]), Decimal: int(desc[17])})
+	}
+
+	r.recordSize = 1
+	for _, f := range r.fields {
+		r.recordSize += f.Length
+	}
+	return r, nil
+}
+
+// Fields returns the reader's field descriptors, in column order.
+func (r *Reader) Fields() []FieldDescriptor { return r.fields }
+
+// NumRecords returns the number of records recorded in the header,
+// including deleted ones.
+func (r *Reader) NumRecords() int { return r.numRecords }
+
+// HeaderSize returns the byte offset of the first record.
+func (r *Reader) HeaderSize() int { return r.headerSize }
+
+// RecordSize returns the fixed byte length of one record, deletion flag
+// included.
+func (r *Reader) RecordSize() int { return r.recordSize }
+
+// Record reads record i, seeking directly to HeaderSize() + i*RecordSize().
+func (r *Reader) Record(i int) (Row, error) {
+	if i < 0 || i >= r.numRecords {
+		return Row{}, fmt.Errorf("dbf: record %d out of range [0,%d)", i, r.numRecords)
+	}
+	buf := make([]byte, r.recordSize)
+	offset := int64(r.headerSize) + int64(i)*int64(r.recordSize)
+	if _, err := r.ra.ReadAt(buf, offset); err != nil {
+		return Row{}, fmt.Errorf("dbf: reading record %d: %w", i, err)
+	}
+	row := Row{fields: r.fields, Deleted: buf[0] == deletedFlag, Values: make([]string, len(r.fields))}
+	pos := 1
+	for i, f := range r.fields {
+		row.Values[i] = strings.TrimSpace(string(buf[pos : pos+f.Length]))
+		pos += f.Length
+	}
+	return row, nil
+}
+
+// Iterate calls fn for every non-deleted record in order, stopping early if
+// fn returns an error or ctx is canceled.
+func (r *Reader) Iterate(ctx context.Context, fn func(Row) error) error {
+	for i := 0; i < r.numRecords; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, err := r.Record(i)
+		if err != nil {
+			return err
+		}
+		if row.Deleted {
+			continue
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writer appends dBASE III records to an io.Writer one at a time, so a
+// multi-gigabyte table can be produced without buffering it in memory.
+//
+// The header written up front claims zero records. Close patches in the
+// real count and writes the trailing EOF byte; patching the count requires
+// the underlying writer to also implement io.Seeker (as *os.File does) — if
+// it doesn't, Close still writes the EOF marker but the header's record
+// count is left at zero, so readers must fall back to scanning for EOF.
+type Writer struct {
+	w          io.Writer
+	fields     []FieldDescriptor
+	headerSize int
+	recordSize int
+	count      int
+	closed     bool
+}
+
+// NewWriter writes the header and field descriptors for fields to w and
+// returns a Writer ready for Append.
+func NewWriter(w io.Writer, fields []FieldDescriptor) (*Writer, error) {
+	wr := &Writer{w: w, fields: fields}
+	wr.recordSize = 1
+	for _, f := range fields {
+		wr.recordSize += f.Length
+	}
+	wr.headerSize = headerSize + len(fields)*fieldDescSize + 1
+
+	var header [headerSize]byte
+	header[0] = 0x03
+	binary.LittleEndian.PutUint16(header[8:10], uint16(wr.headerSize))
+	binary.LittleEndian.PutUint16(header[10:12], uint16(wr.recordSize))
+	if _, err := w.Write(header[:]); err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		var desc [fieldDescSize]byte
+		copy(desc[0:fieldNameSize], f.Name)
+		desc[11] = f.Type
+		desc[16] = byte(f.Length)
+		desc[17] = byte(f.Decimal)
+		if _, err := w.Write(desc[:]); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := w.Write([]byte{fieldArrayEnd}); err != nil {
+		return nil, err
+	}
+	return wr, nil
+}
+
+// Append writes one record, in the field order the Writer was created with.
+// values that are shorter or longer than their field are padded or
+// truncated, the same as DbfTable.SaveFile.
+func (wr *Writer) Append(values []string, deleted bool) error {
+	if len(values) != len(wr.fields) {
+		return fmt.Errorf("dbf: got %d values, want %d", len(values), len(wr.fields))
+	}
+	flag := byte(notDeletedFlag)
+	if deleted {
+		flag = deletedFlag
+	}
+	if _, err := wr.w.Write([]byte{flag}); err != nil {
+		return err
+	}
+	for i, f := range wr.fields {
+		if _, err := wr.w.Write(formatField(values[i], f)); err != nil {
+			return err
+		}
+	}
+	wr.count++
+	return nil
+}
+
+// Close writes the trailing EOF byte and, if the underlying writer supports
+// seeking, patches the header's record count.
+func (wr *Writer) Close() error {
+	if wr.closed {
+		return nil
+	}
+	wr.closed = true
+	if _, err := wr.w.Write([]byte{fileEnd}); err != nil {
+		return err
+	}
+
+	seeker, ok := wr.w.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+	if _, err := seeker.Seek(4, io.SeekStart); err != nil {
+		return err
+	}
+	var count [4]byte
+	binary.LittleEndian.PutUint32(count[:], uint32(wr.count))
+	if _, err := seeker.Write(count[:]); err != nil {
+		return err
+	}
+	_, err := seeker.Seek(0, io.SeekEnd)
+	return err
+}
@@ -0,0 +1,112 @@
+package dbf
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+func streamTestFields() []FieldDescriptor {
+	return []FieldDescriptor{
+		{Name: "name", Type: Character, Length: 10},
+		{Name: "age", Type: Number, Length: 5},
+	}
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	temp, err := os.CreateTemp("", "test_dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := temp.Name()
+	defer os.Remove(path)
+
+	fields := streamTestFields()
+	wr, err := NewWriter(temp, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wr.Append([]string{"alice", "30"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := wr.Append([]string{"bob", "25"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := wr.Append([]string{"carol", "40"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := wr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := temp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rd, err := NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rd.NumRecords() != 3 {
+		t.Fatalf("NumRecords() = %d, want 3", rd.NumRecords())
+	}
+
+	row, err := rd.Record(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !row.Deleted || row.ValueByName("name") != "bob" {
+		t.Fatalf("Record(1) = %+v, want deleted bob", row)
+	}
+
+	var names []string
+	if err := rd.Iterate(context.Background(), func(r Row) error {
+		names = append(names, r.ValueByName("name"))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"alice", "carol"}
+	if len(names) != len(want) {
+		t.Fatalf("Iterate got %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Iterate got %v, want %v", names, want)
+		}
+	}
+}
+
+// TestStreamWriterNonSeekableFallback confirms the documented limitation of
+// Close on a non-seekable io.Writer: it still writes the EOF marker, but
+// can't patch the header's record count, which is left at zero.
+func TestStreamWriterNonSeekableFallback(t *testing.T) {
+	var buf bytes.Buffer
+
+	fields := streamTestFields()
+	wr, err := NewWriter(&buf, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wr.Append([]string{"alice", "30"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := wr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	if count := binary.LittleEndian.Uint32(data[4:8]); count != 0 {
+		t.Fatalf("header record count = %d, want 0 (buffered writer can't seek back to patch it)", count)
+	}
+	if data[len(data)-1] != fileEnd {
+		t.Fatalf("last byte = %#x, want EOF marker %#x", data[len(data)-1], fileEnd)
+	}
+}
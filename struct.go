@@ -0,0 +1,272 @@
+package dbf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "20060102"
+
+// tagOptions holds the parsed form of a `dbf:"NAME,option,..."` struct tag.
+type tagOptions struct {
+	name      string
+	omitempty bool
+	memo      bool
+}
+
+func parseTag(field reflect.StructField) tagOptions {
+	opts := tagOptions{name: field.Name}
+	tag := field.Tag.Get("dbf")
+	if tag == "" {
+		return opts
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] != "" {
+		opts.name = parts[0]
+	}
+	for _, p := range parts[1:] {
+		switch p {
+		case "omitempty":
+			opts.omitempty = true
+		case "memo":
+			opts.memo = true
+		}
+	}
+	return opts
+}
+
+func structType(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("dbf: %T is not a struct", v)
+	}
+	return rv, nil
+}
+
+// Create defines the table's fields from the exported fields of the struct
+// v, in declaration order. Field names and the omitempty option come from
+// the `dbf` struct tag, falling back to the Go field name.
+func (dt *DbfTable) Create(v interface{}) error {
+	rv, err := structType(v)
+	if err != nil {
+		return err
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		opts := parseTag(f)
+		switch f.Type.Kind() {
+		case reflect.Bool:
+			dt.AddBoolField(opts.name)
+		case reflect.String:
+			if opts.memo {
+				dt.AddMemoField(opts.name)
+			} else {
+				dt.AddTextField(opts.name, 254)
+			}
+		case reflect.Slice:
+			if f.Type.Elem().Kind() != reflect.Uint8 {
+				return fmt.Errorf("dbf: unsupported field type %s for %s", f.Type, f.Name)
+			}
+			dt.AddBlobField(opts.name)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			dt.AddIntField(opts.name, 10)
+		case reflect.Float32, reflect.Float64:
+			dt.AddFloatField(opts.name, 20, 6)
+		case reflect.Struct:
+			if f.Type == reflect.TypeOf(time.Time{}) {
+				dt.AddDateField(opts.name)
+				continue
+			}
+			return fmt.Errorf("dbf: unsupported field type %s for %s", f.Type, f.Name)
+		default:
+			return fmt.Errorf("dbf: unsupported field type %s for %s", f.Type, f.Name)
+		}
+	}
+	return nil
+}
+
+// Append adds a new record and writes v into it, returning the new row
+// number. It's equivalent to AddRecord followed by Write.
+func (dt *DbfTable) Append(v interface{}) (int, error) {
+	row := dt.AddRecord()
+	if err := dt.Write(row, v); err != nil {
+		return row, err
+	}
+	return row, nil
+}
+
+// Write encodes the exported fields of v, in declaration order, into row.
+// The struct's fields must line up positionally with the table's fields, as
+// set up by Create or the matching sequence of AddXField calls.
+func (dt *DbfTable) Write(row int, v interface{}) error {
+	rv, err := structType(v)
+	if err != nil {
+		return err
+	}
+	rt := rv.Type()
+	fields := dt.Fields()
+	field := 0
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		opts := parseTag(sf)
+		fv := rv.Field(i)
+
+		switch fields[field].Type {
+		case Memo:
+			if err := dt.SetMemoValue(row, field, fv.String()); err != nil {
+				return fmt.Errorf("dbf: field %s: %w", sf.Name, err)
+			}
+		case General:
+			if err := dt.SetBlobValue(row, field, fv.Bytes()); err != nil {
+				return fmt.Errorf("dbf: field %s: %w", sf.Name, err)
+			}
+		default:
+			value, err := formatValue(fv)
+			if err != nil {
+				return fmt.Errorf("dbf: field %s: %w", sf.Name, err)
+			}
+			if opts.omitempty && fv.IsZero() {
+				value = ""
+			}
+			dt.SetFieldValue(row, field, value)
+		}
+		field++
+	}
+	return nil
+}
+
+// Read decodes row into v, which must be a pointer to a struct whose
+// exported fields line up positionally with the table's fields.
+func (dt *DbfTable) Read(row int, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dbf: Read requires a pointer to a struct, got %T", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	fields := dt.Fields()
+	field := 0
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		switch fields[field].Type {
+		case Memo:
+			text, err := dt.MemoValue(row, field)
+			if err != nil {
+				return fmt.Errorf("dbf: field %s: %w", sf.Name, err)
+			}
+			rv.Field(i).SetString(text)
+		case General:
+			data, err := dt.BlobValue(row, field)
+			if err != nil {
+				return fmt.Errorf("dbf: field %s: %w", sf.Name, err)
+			}
+			rv.Field(i).SetBytes(data)
+		default:
+			if err := parseValue(dt.FieldValue(row, field), rv.Field(i)); err != nil {
+				return fmt.Errorf("dbf: field %s: %w", sf.Name, err)
+			}
+		}
+		field++
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return "t", nil
+		}
+		return "f", nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			if t.IsZero() {
+				return "", nil
+			}
+			return t.Format(dateLayout), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func parseValue(value string, v reflect.Value) error {
+	value = strings.TrimSpace(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(value == "t" || value == "T")
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			v.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			v.SetUint(0)
+			return nil
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			v.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Struct:
+		if _, ok := v.Interface().(time.Time); ok {
+			if value == "" {
+				v.Set(reflect.ValueOf(time.Time{}))
+				return nil
+			}
+			t, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(t))
+			return nil
+		}
+		return fmt.Errorf("unsupported type %s", v.Type())
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package dbf
+
+import (
+	"fmt"
+	"os"
+)
+
+// Tx gives an Update or View callback access to a DbfTable. Mutating
+// methods on a Tx opened by View return an error instead of changing the
+// table.
+//
+// A writable Tx doesn't touch its table at all until Update commits:
+// AddRecord/Delete/SetFieldValue* stage their changes in the Tx itself, and
+// Update applies the whole batch to the table in one step, only once fn
+// returns nil. So a reader racing an in-flight Update (directly, or through
+// a concurrent View) always sees either the pre-transaction state or the
+// fully committed one, never a partial write.
+type Tx struct {
+	table    *DbfTable
+	readOnly bool
+
+	baseRows    int               // table's row count when the Tx was opened
+	newRows     [][]string        // records staged by AddRecord, in order
+	newDeleted  []bool            // deletion flags for newRows, parallel to it
+	fieldEdits  map[[2]int]string // (row, field) -> value, for pre-existing rows
+	deletedRows map[int]bool      // row -> true, for pre-existing rows
+}
+
+var errReadOnlyTx = fmt.Errorf("dbf: table is read-only inside View")
+
+// newTx opens a Tx on dt, staging writes against dt's row count at the time
+// of the call.
+func newTx(dt *DbfTable, readOnly bool) *Tx {
+	return &Tx{
+		table:       dt,
+		readOnly:    readOnly,
+		baseRows:    dt.NumRecords(),
+		fieldEdits:  make(map[[2]int]string),
+		deletedRows: make(map[int]bool),
+	}
+}
+
+// AddRecord stages a new, empty record and returns its row number. See
+// DbfTable.AddRecord.
+func (tx *Tx) AddRecord() (int, error) {
+	if tx.readOnly {
+		return 0, errReadOnlyTx
+	}
+	row := tx.baseRows + len(tx.newRows)
+	tx.newRows = append(tx.newRows, make([]string, len(tx.table.Fields())))
+	tx.newDeleted = append(tx.newDeleted, false)
+	return row, nil
+}
+
+// Delete marks row as deleted. See DbfTable.Delete.
+func (tx *Tx) Delete(row int) error {
+	if tx.readOnly {
+		return errReadOnlyTx
+	}
+	if i := row - tx.baseRows; i >= 0 {
+		tx.newDeleted[i] = true
+		return nil
+	}
+	tx.deletedRows[row] = true
+	return nil
+}
+
+// SetFieldValue sets the value of field in row. See DbfTable.SetFieldValue.
+func (tx *Tx) SetFieldValue(row, field int, value string) error {
+	if tx.readOnly {
+		return errReadOnlyTx
+	}
+	if i := row - tx.baseRows; i >= 0 {
+		tx.newRows[i][field] = value
+		return nil
+	}
+	tx.fieldEdits[[2]int{row, field}] = value
+	return nil
+}
+
+// SetFieldValueByName sets the value of the named field in row. See
+// DbfTable.SetFieldValueByName.
+func (tx *Tx) SetFieldValueByName(row int, name, value string) error {
+	if tx.readOnly {
+		return errReadOnlyTx
+	}
+	field := tx.table.FieldIndex(name)
+	if field < 0 {
+		return fmt.Errorf("dbf: unknown field %q", name)
+	}
+	return tx.SetFieldValue(row, field, value)
+}
+
+// NumRecords returns the number of records visible to the transaction,
+// including deleted ones and ones staged by this Tx's own AddRecord calls.
+func (tx *Tx) NumRecords() int {
+	return tx.baseRows + len(tx.newRows)
+}
+
+// FieldValue returns the value of field in row, reflecting this Tx's own
+// staged writes even before they're committed.
+func (tx *Tx) FieldValue(row, field int) string {
+	if i := row - tx.baseRows; i >= 0 {
+		return tx.newRows[i][field]
+	}
+	if v, ok := tx.fieldEdits[[2]int{row, field}]; ok {
+		return v
+	}
+	return tx.table.FieldValue(row, field)
+}
+
+// FieldValueByName returns the value of the named field in row.
+func (tx *Tx) FieldValueByName(row int, name string) string {
+	return tx.FieldValue(row, tx.table.FieldIndex(name))
+}
+
+// Row returns a copy of the field values of row.
+func (tx *Tx) Row(row int) []string {
+	if i := row - tx.baseRows; i >= 0 {
+		out := make([]string, len(tx.newRows[i]))
+		copy(out, tx.newRows[i])
+		return out
+	}
+	row0 := tx.table.Row(row)
+	out := make([]string, len(row0))
+	copy(out, row0)
+	for rf, v := range tx.fieldEdits {
+		if rf[0] == row {
+			out[rf[1]] = v
+		}
+	}
+	return out
+}
+
+// IsDeleted reports whether row is marked as deleted.
+func (tx *Tx) IsDeleted(row int) bool {
+	if i := row - tx.baseRows; i >= 0 {
+		return tx.newDeleted[i]
+	}
+	if tx.deletedRows[row] {
+		return true
+	}
+	return tx.table.IsDeleted(row)
+}
+
+// Update runs fn against a writable Tx on dt. fn's writes are staged in the
+// Tx and never touch dt until fn returns nil, at which point they're applied
+// to dt in one step and committed: written to dt's WAL sidecar and fsynced,
+// applied to dt.path, and the WAL truncated. If fn returns an error, nothing
+// it staged is ever applied, so there's nothing to roll back. dt must have a
+// path (set by LoadFile or SaveFile); otherwise the changes are kept in
+// memory but nothing is persisted.
+//
+// Update holds dt's transaction lock for the whole call, so concurrent
+// Updates on the same table run one at a time. Combined with staging writes
+// in the Tx, this also means a concurrent View or direct read of dt never
+// observes a partial transaction: dt only ever reflects the state before
+// Update was called, or the fully committed state after.
+func (dt *DbfTable) Update(fn func(tx *Tx) error) error {
+	dt.txMu.Lock()
+	defer dt.txMu.Unlock()
+
+	tx := newTx(dt, false)
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	snapshot := dt.Clone()
+	dt.applyTx(tx)
+
+	if dt.path == "" {
+		return nil
+	}
+	if err := dt.commitWAL(); err != nil {
+		dt.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+// View runs fn against a read-only Tx on dt: its mutating methods all
+// return an error instead of changing dt.
+func (dt *DbfTable) View(fn func(tx *Tx) error) error {
+	return fn(newTx(dt, true))
+}
+
+// applyTx merges a writable Tx's staged records, field edits and deletions
+// into dt in one locked step, once Update's fn has returned successfully.
+func (dt *DbfTable) applyTx(tx *Tx) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	for i, row := range tx.newRows {
+		dt.records = append(dt.records, row)
+		dt.deleted = append(dt.deleted, tx.newDeleted[i])
+	}
+	for rf, v := range tx.fieldEdits {
+		dt.records[rf[0]][rf[1]] = v
+	}
+	for row := range tx.deletedRows {
+		dt.deleted[row] = true
+	}
+}
+
+// restore resets dt's fields, field index and records to a prior Clone,
+// rolling back a commit whose WAL write failed partway through.
+func (dt *DbfTable) restore(snapshot *DbfTable) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	dt.fields = snapshot.fields
+	dt.fieldIdx = snapshot.fieldIdx
+	dt.records = snapshot.records
+	dt.deleted = snapshot.deleted
+}
+
+// walPath returns the WAL sidecar path for a table stored at path.
+func walPath(path string) string {
+	return path + ".wal"
+}
+
+// commitWAL persists dt's current in-memory state to its path: the new
+// state is written to the WAL sidecar first and fsynced, then written to
+// dt.path itself, and finally the WAL is truncated to empty. A crash
+// between any of these steps leaves enough information for recoverWAL to
+// finish the commit (or safely discard it) the next time the file is
+// loaded.
+func (dt *DbfTable) commitWAL() error {
+	wal := walPath(dt.path)
+
+	wf, err := os.OpenFile(wal, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("dbf: opening WAL: %w", err)
+	}
+	if err := dt.encodeTo(wf); err != nil {
+		wf.Close()
+		return fmt.Errorf("dbf: writing WAL: %w", err)
+	}
+	if err := wf.Sync(); err != nil {
+		wf.Close()
+		return fmt.Errorf("dbf: fsyncing WAL: %w", err)
+	}
+	if err := wf.Close(); err != nil {
+		return fmt.Errorf("dbf: closing WAL: %w", err)
+	}
+
+	if err := dt.SaveFile(dt.path); err != nil {
+		return fmt.Errorf("dbf: applying WAL to %s: %w", dt.path, err)
+	}
+
+	if err := os.Truncate(wal, 0); err != nil {
+		return fmt.Errorf("dbf: truncating WAL: %w", err)
+	}
+	return nil
+}
+
+// recoverWAL checks path's WAL sidecar before it's loaded. An empty or
+// missing WAL means the last commitWAL either fully finished or never got
+// past opening the file, so there's nothing to do. A non-empty WAL that
+// decodes cleanly is the state of a commit that reached the fsync but
+// crashed before (or while) being applied to path, so it's replayed onto
+// path. A non-empty WAL that fails to decode was only partially written
+// when the crash happened; path is still the last good commit, so the WAL
+// is simply discarded.
+func recoverWAL(path string) error {
+	wal := walPath(path)
+
+	info, err := os.Stat(wal)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("dbf: statting WAL: %w", err)
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	wf, err := os.Open(wal)
+	if err != nil {
+		return fmt.Errorf("dbf: opening WAL: %w", err)
+	}
+	dt, decodeErr := decode(wf)
+	wf.Close()
+	if decodeErr != nil {
+		return os.Truncate(wal, 0)
+	}
+
+	if err := dt.SaveFile(path); err != nil {
+		return fmt.Errorf("dbf: replaying WAL to %s: %w", path, err)
+	}
+	return os.Truncate(wal, 0)
+}